@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objmodel // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/elasticsearchexporter/internal/objmodel"
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CustomMapper is a Mapper that renames keys according to a user-supplied
+// table, leaving any key without an entry in the table unchanged. It is
+// typically loaded from a YAML file with LoadCustomMapper.
+type CustomMapper struct {
+	// Rename maps a Document key to the key it should be serialized as. An
+	// empty value for a key drops the field entirely.
+	Rename map[string]string
+	// Dedot controls whether a dotted key in the renamed output is expanded
+	// into nested JSON objects, as Serialize(_, true) does.
+	Dedot bool
+}
+
+func (m CustomMapper) MapKey(key string) (string, bool) {
+	esKey, ok := m.Rename[key]
+	if !ok {
+		return key, false
+	}
+	if esKey == "" {
+		return "", true
+	}
+	return esKey, false
+}
+
+func (m CustomMapper) Nested() bool { return m.Dedot }
+
+// customMapperConfig is the on-disk shape LoadCustomMapper expects, e.g.:
+//
+//	dedot: true
+//	rename:
+//	  http.method: http.request.method
+//	  internal.debug.trace_flags: "" # drop the field
+type customMapperConfig struct {
+	Dedot  bool              `yaml:"dedot"`
+	Rename map[string]string `yaml:"rename"`
+}
+
+// LoadCustomMapper reads a YAML rename table from path and returns the
+// resulting CustomMapper.
+func LoadCustomMapper(path string) (CustomMapper, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return CustomMapper{}, fmt.Errorf("reading custom mapper config %q: %w", path, err)
+	}
+
+	var cfg customMapperConfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return CustomMapper{}, fmt.Errorf("parsing custom mapper config %q: %w", path, err)
+	}
+
+	return CustomMapper{Rename: cfg.Rename, Dedot: cfg.Dedot}, nil
+}
@@ -0,0 +1,143 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objmodel // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/elasticsearchexporter/internal/objmodel"
+
+import (
+	"io"
+	"math"
+
+	"github.com/elastic/go-structform/json"
+)
+
+// Mapper controls how a Document's internal, flattened keys are translated
+// into the keys of the serialized output, letting operators pick an output
+// convention (flat, dedot, ECS, ...) per exporter instance instead of the
+// boolean dedot flag Serialize takes.
+type Mapper interface {
+	// MapKey translates key - a Document key in its internal, dotted form -
+	// into the key it should be serialized as. drop reports whether the
+	// field should be omitted from the output entirely.
+	MapKey(key string) (esKey string, drop bool)
+	// Nested reports whether a dotted key returned by MapKey should be
+	// expanded into nested JSON objects (as Serialize(_, true) does), or
+	// kept as a single flat key (as Serialize(_, false) does).
+	Nested() bool
+}
+
+// valueMapper is an optional interface a Mapper can implement to coerce a
+// field's value - for example rounding a status code reported as a double
+// into the integer ("long") type a mapping convention requires.
+type valueMapper interface {
+	MapValue(key string, v Value) Value
+}
+
+// FlatMapper is the identity Mapper that reproduces Serialize(_, false):
+// keys are left exactly as stored, and are not expanded into nested objects.
+type FlatMapper struct{}
+
+func (FlatMapper) MapKey(key string) (string, bool) { return key, false }
+func (FlatMapper) Nested() bool                     { return false }
+
+// DedotMapper is the identity Mapper that reproduces Serialize(_, true):
+// keys are left exactly as stored, but dotted keys are expanded into nested
+// objects.
+type DedotMapper struct{}
+
+func (DedotMapper) MapKey(key string) (string, bool) { return key, false }
+func (DedotMapper) Nested() bool                     { return true }
+
+// ECSMapper rewrites well-known OTel semantic-convention keys into their
+// Elastic Common Schema equivalents, dropping or coercing values where ECS
+// requires a different shape. Keys with no known ECS equivalent are passed
+// through unchanged. Output is always nested, since ECS field names are
+// themselves dotted namespaces (e.g. "http.request.method").
+type ECSMapper struct{}
+
+func (ECSMapper) MapKey(key string) (string, bool) {
+	rule, ok := ecsKeyTable[key]
+	if !ok {
+		return key, false
+	}
+	if rule.esKey == "" {
+		return "", true
+	}
+	return rule.esKey, false
+}
+
+func (ECSMapper) Nested() bool { return true }
+
+func (ECSMapper) MapValue(key string, v Value) Value {
+	if rule, ok := ecsKeyTable[key]; ok && rule.coerce != nil {
+		return rule.coerce(v)
+	}
+	return v
+}
+
+type ecsRule struct {
+	// esKey is the ECS field name key should be rewritten to. An empty
+	// esKey means the field should be dropped.
+	esKey string
+	// coerce, if set, adjusts the value to the type the ECS field expects.
+	coerce func(Value) Value
+}
+
+var ecsKeyTable = map[string]ecsRule{
+	"http.method":                  {esKey: "http.request.method"},
+	"http.status_code":             {esKey: "http.response.status_code", coerce: coerceLong},
+	"http.response_content_length": {esKey: "http.response.body.bytes", coerce: coerceLong},
+	"net.peer.ip":                  {esKey: "source.ip"},
+	"net.peer.port":                {esKey: "source.port", coerce: coerceLong},
+	"net.host.ip":                  {esKey: "destination.ip"},
+	"net.host.port":                {esKey: "destination.port", coerce: coerceLong},
+	"service.name":                 {esKey: "service.name"},
+	"service.version":              {esKey: "service.version"},
+	"service.instance.id":          {esKey: "service.node.name"},
+}
+
+// coerceLong rounds a double-valued field to the nearest int64, the shape
+// ECS's "long" field type requires; other kinds are left untouched.
+func coerceLong(v Value) Value {
+	if v.kind != KindDouble {
+		return v
+	}
+	return intValue(int64(math.Round(math.Float64frombits(v.primitive))))
+}
+
+// SerializeWith writes doc as a JSON object to w, using m to translate and
+// optionally drop or coerce each field. m is consulted after Dedup but
+// before JSON emission.
+func (doc *Document) SerializeWith(w io.Writer, m Mapper) error {
+	mapped := make([]field, 0, len(doc.fields))
+	for _, f := range doc.fields {
+		if f.value.kind == KindIgnore {
+			continue
+		}
+		esKey, drop := m.MapKey(f.key)
+		if drop {
+			continue
+		}
+		value := f.value
+		if vm, ok := m.(valueMapper); ok {
+			value = vm.MapValue(f.key, value)
+		}
+		mapped = append(mapped, field{key: esKey, value: value})
+	}
+
+	// A Mapper can remap two originally-unrelated keys into a namespace
+	// collision (e.g. a primitive at "a" and another key renamed to
+	// "a.b"), so sort before lifting - liftNamespaceValues only compares
+	// adjacent keys - and again afterwards, since the lift itself can
+	// change a key's sorted position relative to its new siblings.
+	sortFields(mapped)
+	liftNamespaceValues(mapped)
+	sortFields(mapped)
+	dedupSortedFields(mapped)
+
+	out := Document{fields: mapped}
+	v := json.NewVisitor(w)
+	if m.Nested() {
+		return out.iterJSONDedot(v)
+	}
+	return out.iterJSONFlat(v)
+}
@@ -0,0 +1,119 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objmodel
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_SerializeWith(t *testing.T) {
+	tests := map[string]struct {
+		mapper Mapper
+		want   string
+	}{
+		"FlatMapper matches Serialize(_, false)": {
+			mapper: FlatMapper{},
+			want:   `{"a.i":1,"a.str":"test"}`,
+		},
+		"DedotMapper matches Serialize(_, true)": {
+			mapper: DedotMapper{},
+			want:   `{"a":{"i":1,"str":"test"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var doc Document
+			doc.AddString("a.str", "test")
+			doc.AddInt("a.i", 1)
+			doc.Sort()
+			doc.Dedup()
+
+			var buf strings.Builder
+			require.NoError(t, doc.SerializeWith(&buf, test.mapper))
+			assert.Equal(t, test.want, buf.String())
+		})
+	}
+}
+
+func TestECSMapper(t *testing.T) {
+	var doc Document
+	doc.AddString("http.method", "GET")
+	doc.AddDouble("http.status_code", 200)
+	doc.AddString("unmapped.field", "kept as-is")
+	doc.Sort()
+	doc.Dedup()
+
+	var buf strings.Builder
+	require.NoError(t, doc.SerializeWith(&buf, ECSMapper{}))
+	assert.Equal(t, `{"http":{"request":{"method":"GET"},"response":{"status_code":200}},"unmapped":{"field":"kept as-is"}}`, buf.String())
+}
+
+func TestDocument_SerializeWith_NamespaceCollisionFromMapper(t *testing.T) {
+	// A Mapper can rename two originally-unrelated keys into a primitive/
+	// namespace collision that didn't exist in the source Document, so
+	// SerializeWith must re-run the same namespace-lift Dedup would.
+	var doc Document
+	doc.AddString("x", "leaf")
+	doc.AddString("y", "nested")
+	doc.Sort()
+	doc.Dedup()
+
+	m := CustomMapper{
+		Rename: map[string]string{"x": "a", "y": "a.b"},
+		Dedot:  true,
+	}
+
+	var buf strings.Builder
+	require.NoError(t, doc.SerializeWith(&buf, m))
+	assert.Equal(t, `{"a":{"b":"nested","value":"leaf"}}`, buf.String())
+}
+
+func TestCustomMapper(t *testing.T) {
+	t.Run("renames and drops according to the table", func(t *testing.T) {
+		m := CustomMapper{
+			Rename: map[string]string{
+				"a":        "b",
+				"internal": "",
+			},
+		}
+
+		esKey, drop := m.MapKey("a")
+		assert.Equal(t, "b", esKey)
+		assert.False(t, drop)
+
+		_, drop = m.MapKey("internal")
+		assert.True(t, drop)
+
+		esKey, drop = m.MapKey("untouched")
+		assert.Equal(t, "untouched", esKey)
+		assert.False(t, drop)
+	})
+
+	t.Run("LoadCustomMapper reads a YAML rename table", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mapper.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(""+
+			"dedot: true\n"+
+			"rename:\n"+
+			"  http.method: http.request.method\n"+
+			"  internal.debug: \"\"\n"), 0o600))
+
+		m, err := LoadCustomMapper(path)
+		require.NoError(t, err)
+
+		assert.True(t, m.Nested())
+		esKey, drop := m.MapKey("http.method")
+		assert.Equal(t, "http.request.method", esKey)
+		assert.False(t, drop)
+
+		_, drop = m.MapKey("internal.debug")
+		assert.True(t, drop)
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objmodel // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/elasticsearchexporter/internal/objmodel"
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// MergeMode controls how Merge resolves a collision between two primitive
+// values that both set the same key.
+type MergeMode uint8
+
+const (
+	// MergeOverwrite keeps the value from the Document passed to Merge,
+	// discarding the receiver's value. This is the default zero value.
+	MergeOverwrite MergeMode = iota
+	// MergePreserve keeps the receiver's existing value, discarding the one
+	// passed to Merge.
+	MergePreserve
+	// MergeAppend keeps both values, turning the field into a two-element
+	// array.
+	MergeAppend
+)
+
+// MergeOptions configures Document.Merge.
+type MergeOptions struct {
+	Mode MergeMode
+}
+
+// Merge deep-merges other into doc, in the spirit of Beats' MapStr.DeepUpdate:
+// for every key present on both sides, if both values are objects (including
+// dotted-path namespaces that decode to the same object) their fields are
+// merged recursively; arrays of scalars are concatenated; arrays of objects
+// are merged element-wise by index; and any other collision between two
+// primitive values is resolved according to opts.Mode. Keys present on only
+// one side are kept as-is.
+//
+// Merge leaves doc sorted and deduped, as required by Serialize.
+func (doc *Document) Merge(other Document, opts MergeOptions) {
+	// toObject/insertPath has no equivalent of Dedup's namespace-lift: a
+	// primitive at "k" alongside a field at "k.child" - legal on either
+	// side, e.g. straight off a single pcommon.Map - would otherwise have
+	// insertPath silently clobber the primitive instead of lifting it to
+	// "k.value". Normalize both sides first so that ambiguity is already
+	// resolved the way Dedup resolves it elsewhere.
+	doc.Sort()
+	doc.Dedup()
+	other.Sort()
+	other.Dedup()
+
+	merged := doc.toObject().mergeObject(other.toObject(), opts)
+
+	doc.fields = doc.fields[:0]
+	doc.flattenInto(merged)
+	doc.Sort()
+	doc.Dedup()
+}
+
+// MergeAttributes merges the attributes in m into the document under key,
+// using the same rules as Merge.
+func (doc *Document) MergeAttributes(key string, m pcommon.Map, opts MergeOptions) {
+	var other Document
+	other.AddAttributes(key, m)
+	doc.Merge(other, opts)
+}
+
+// toObject rebuilds doc's flattened fields into a single nested KindObject
+// Value, so that a dotted key on one side and a nested object on the other
+// compare equal during mergeObject.
+func (doc *Document) toObject() Value {
+	root := Value{kind: KindObject}
+	for _, f := range doc.fields {
+		if f.value.kind == KindIgnore {
+			continue
+		}
+		root = root.insertPath(strings.Split(f.key, "."), f.value)
+	}
+	return root
+}
+
+func (v Value) insertPath(path []string, leaf Value) Value {
+	if v.kind != KindObject {
+		v = Value{kind: KindObject}
+	}
+
+	key := path[0]
+	idx := -1
+	for i := range v.obj {
+		if v.obj[i].key == key {
+			idx = i
+			break
+		}
+	}
+
+	if len(path) == 1 {
+		if idx >= 0 {
+			v.obj[idx].value = leaf
+		} else {
+			v.obj = append(v.obj, field{key: key, value: leaf})
+		}
+		return v
+	}
+
+	var child Value
+	if idx >= 0 {
+		child = v.obj[idx].value
+	}
+	child = child.insertPath(path[1:], leaf)
+	if idx >= 0 {
+		v.obj[idx].value = child
+	} else {
+		v.obj = append(v.obj, field{key: key, value: child})
+	}
+	return v
+}
+
+// flattenInto appends root's fields back onto doc in dotted-key form.
+func (doc *Document) flattenInto(root Value) {
+	for _, f := range root.obj {
+		doc.flattenValue(f.key, f.value)
+	}
+}
+
+func (doc *Document) flattenValue(prefix string, v Value) {
+	if v.kind != KindObject {
+		doc.Add(prefix, v)
+		return
+	}
+	for _, f := range v.obj {
+		doc.flattenValue(flattenKey(prefix, f.key), f.value)
+	}
+}
+
+// mergeObject deep-merges other into v, following the rules documented on
+// Merge.
+func (v Value) mergeObject(other Value, opts MergeOptions) Value {
+	switch {
+	case v.kind == KindObject && other.kind == KindObject:
+		return mergeObjectValues(v, other, opts)
+	case v.kind == KindArr && other.kind == KindArr:
+		return mergeArrayValues(v, other, opts)
+	case v.kind == KindArr || other.kind == KindArr:
+		va, ob := v, other
+		if va.kind != KindArr {
+			va = arrValue(va)
+		}
+		if ob.kind != KindArr {
+			ob = arrValue(ob)
+		}
+		return mergeArrayValues(va, ob, opts)
+	default:
+		return mergePrimitiveValues(v, other, opts)
+	}
+}
+
+func mergeObjectValues(a, b Value, opts MergeOptions) Value {
+	result := Value{kind: KindObject}
+	idx := make(map[string]int, len(a.obj))
+	for _, f := range a.obj {
+		idx[f.key] = len(result.obj)
+		result.obj = append(result.obj, f)
+	}
+	for _, f := range b.obj {
+		if i, ok := idx[f.key]; ok {
+			result.obj[i].value = result.obj[i].value.mergeObject(f.value, opts)
+			continue
+		}
+		idx[f.key] = len(result.obj)
+		result.obj = append(result.obj, f)
+	}
+	return result
+}
+
+func mergeArrayValues(a, b Value, opts MergeOptions) Value {
+	if arrHasObjects(a) || arrHasObjects(b) {
+		n := len(a.arr)
+		if len(b.arr) > n {
+			n = len(b.arr)
+		}
+		out := make([]Value, n)
+		for i := 0; i < n; i++ {
+			switch {
+			case i >= len(a.arr):
+				out[i] = b.arr[i]
+			case i >= len(b.arr):
+				out[i] = a.arr[i]
+			default:
+				out[i] = a.arr[i].mergeObject(b.arr[i], opts)
+			}
+		}
+		return Value{kind: KindArr, arr: out}
+	}
+
+	out := make([]Value, 0, len(a.arr)+len(b.arr))
+	out = append(out, a.arr...)
+	out = append(out, b.arr...)
+	return Value{kind: KindArr, arr: out}
+}
+
+func arrHasObjects(v Value) bool {
+	for _, elem := range v.arr {
+		if elem.kind == KindObject {
+			return true
+		}
+	}
+	return false
+}
+
+func mergePrimitiveValues(a, b Value, opts MergeOptions) Value {
+	switch opts.Mode {
+	case MergePreserve:
+		return a
+	case MergeAppend:
+		return arrValue(a, b)
+	default: // MergeOverwrite
+		return b
+	}
+}
@@ -0,0 +1,451 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package objmodel defines an internal data model used to translate OTel attribute
+// maps (resource, scope, record) into the JSON documents the Elasticsearch exporter
+// sends to the bulk API.
+//
+// The model keeps attributes in a flattened, dotted-key representation internally
+// (e.g. "a.b") regardless of whether the source attribute was nested or already
+// dotted, and only reconstructs nested objects at serialization time when the
+// "dedot" output mode is requested. This mirrors the dual representation Elastic's
+// own Beats libbeat `MapStr` uses, and lets the exporter support both conventions
+// without keeping two copies of the document around.
+package objmodel // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/elasticsearchexporter/internal/objmodel"
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	structform "github.com/elastic/go-structform"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Document is an intermediate representation for a JSON document being built for
+// the Elasticsearch exporter. It provides support for appending and de-duplicating
+// fields, and provides convenience functions for writing the document as JSON
+// to an io.Writer via the Serialize method.
+type Document struct {
+	fields []field
+}
+
+// field is a single key/value pair in a Document. The key is always stored in its
+// flattened, dotted form (e.g. "resource.attributes.service.name").
+type field struct {
+	key   string
+	value Value
+}
+
+// Kind enumerates the possible kinds of a Value.
+type Kind uint8
+
+const (
+	KindNil Kind = iota
+	KindBool
+	KindInt
+	KindDouble
+	KindString
+	KindArr
+	KindObject
+	KindTimestamp
+	// KindIgnore marks a field that has been superseded by another field with the
+	// same key. Dedup uses this instead of removing the field outright so that
+	// fields keep stable indices while a Document is being built up.
+	KindIgnore
+)
+
+// Value is a concrete value held by a field or array/object element.
+type Value struct {
+	kind      Kind
+	primitive uint64
+	str       string
+	ts        time.Time
+	arr       []Value
+	obj       []field
+}
+
+var nilValue = Value{kind: KindNil}
+var ignoreValue = Value{kind: KindIgnore}
+
+func boolValue(b bool) Value {
+	var v uint64
+	if b {
+		v = 1
+	}
+	return Value{kind: KindBool, primitive: v}
+}
+
+func intValue(i int64) Value {
+	return Value{kind: KindInt, primitive: uint64(i)}
+}
+
+func doubleValue(d float64) Value {
+	return Value{kind: KindDouble, primitive: math.Float64bits(d)}
+}
+
+func stringValue(s string) Value {
+	return Value{kind: KindString, str: s}
+}
+
+func timestampValue(ts time.Time) Value {
+	return Value{kind: KindTimestamp, ts: ts}
+}
+
+func arrValue(values ...Value) Value {
+	return Value{kind: KindArr, arr: values}
+}
+
+// Add appends a field with the given (already flattened) key and value.
+func (doc *Document) Add(key string, v Value) {
+	doc.fields = append(doc.fields, field{key: key, value: v})
+}
+
+// AddString adds a string valued field. Empty strings are dropped, matching the
+// behavior of AddAttribute for empty attribute values.
+func (doc *Document) AddString(key string, v string) {
+	if v != "" {
+		doc.Add(key, stringValue(v))
+	}
+}
+
+// AddInt adds an int valued field.
+func (doc *Document) AddInt(key string, v int64) {
+	doc.Add(key, intValue(v))
+}
+
+// AddDouble adds a double valued field.
+func (doc *Document) AddDouble(key string, v float64) {
+	doc.Add(key, doubleValue(v))
+}
+
+// AddBool adds a bool valued field.
+func (doc *Document) AddBool(key string, v bool) {
+	doc.Add(key, boolValue(v))
+}
+
+// AddTimestamp adds a timestamp valued field, serialized as RFC3339 with
+// nanosecond precision.
+func (doc *Document) AddTimestamp(key string, ts time.Time) {
+	doc.Add(key, timestampValue(ts))
+}
+
+// AddAttribute adds a single value taken from a pcommon.Value, flattening it
+// under key if it is a map.
+func (doc *Document) AddAttribute(key string, v pcommon.Value) {
+	switch v.Type() {
+	case pcommon.ValueTypeEmpty:
+		// drop nil/empty attributes entirely, rather than emitting a null.
+	case pcommon.ValueTypeMap:
+		doc.AddAttributes(key, v.Map())
+	default:
+		doc.Add(key, valueFromAttribute(v))
+	}
+}
+
+// AddAttributes flattens the given pcommon.Map into the document, prefixing
+// each resulting key with key (using "." as a separator, omitted if key is
+// empty).
+func (doc *Document) AddAttributes(key string, m pcommon.Map) {
+	m.Range(func(k string, v pcommon.Value) bool {
+		doc.AddAttribute(flattenKey(key, k), v)
+		return true
+	})
+}
+
+func flattenKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// Sort sorts the document's fields by key. Sorting is stable, so fields sharing
+// a key retain their relative insertion order; Dedup relies on this.
+func (doc *Document) Sort() {
+	sort.SliceStable(doc.fields, func(i, j int) bool {
+		return doc.fields[i].key < doc.fields[j].key
+	})
+	for i := range doc.fields {
+		doc.fields[i].value.Sort()
+	}
+}
+
+// Sort recursively sorts the fields of an object value, or of every object
+// held inside an array value, by key. Document.Sort calls this for every
+// top-level field so that nested objects - including ones inside arrays,
+// such as OTel span events flattened into a single attribute - serialize
+// deterministically too.
+func (v *Value) Sort() {
+	switch v.kind {
+	case KindObject:
+		sortFields(v.obj)
+		for i := range v.obj {
+			v.obj[i].value.Sort()
+		}
+	case KindArr:
+		for i := range v.arr {
+			v.arr[i].Sort()
+		}
+	}
+}
+
+func sortFields(fields []field) {
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].key < fields[j].key
+	})
+}
+
+// Dedup removes duplicate fields from the document, in place. Dedup must be
+// called on an already-sorted Document (see Sort).
+//
+// There are two kinds of collision Dedup resolves:
+//
+//   - Two fields sharing the exact same key: the earlier field(s) are replaced
+//     with an ignored placeholder and the last one wins, matching the behavior
+//     of a JSON object literal with repeated keys.
+//   - A field whose key is itself a dotted namespace of other fields (e.g. a
+//     primitive value at "namespace" alongside "namespace.a"): the primitive
+//     is moved to "namespace.value" so the namespace can still hold nested
+//     fields, following the same convention Dedup then uses to resolve any
+//     resulting collision with a literal "namespace.value" field.
+func (doc *Document) Dedup() {
+	liftNamespaceValues(doc.fields)
+
+	sort.SliceStable(doc.fields, func(i, j int) bool {
+		return doc.fields[i].key < doc.fields[j].key
+	})
+
+	dedupSortedFields(doc.fields)
+
+	for i := range doc.fields {
+		doc.fields[i].value.Dedup()
+	}
+}
+
+func liftNamespaceValues(fields []field) {
+	for i := range fields {
+		key := fields[i].key
+		if i+1 < len(fields) && strings.HasPrefix(fields[i+1].key, key+".") {
+			fields[i].key = key + ".value"
+		}
+	}
+}
+
+func dedupSortedFields(fields []field) {
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].key == fields[i].key {
+			j++
+		}
+		for k := i; k < j-1; k++ {
+			fields[k].value = ignoreValue
+		}
+		i = j
+	}
+}
+
+// Dedup recursively applies the same collision rules Document.Dedup uses to
+// an object value, or to every object held inside an array value. v must
+// already be sorted (see Sort).
+func (v *Value) Dedup() {
+	switch v.kind {
+	case KindObject:
+		liftNamespaceValues(v.obj)
+		sortFields(v.obj)
+		dedupSortedFields(v.obj)
+		for i := range v.obj {
+			v.obj[i].value.Dedup()
+		}
+	case KindArr:
+		for i := range v.arr {
+			v.arr[i].Dedup()
+		}
+	}
+}
+
+// Serialize writes doc as a JSON object to w. If dedot is true, dotted keys are
+// expanded into nested objects; otherwise keys are written as-is. Serialize
+// assumes the document has already been sorted and deduped.
+//
+// Serialize is a thin convenience wrapper around SerializeWith for the two
+// built-in output conventions; callers that want ECS or a custom key mapping
+// should call SerializeWith directly.
+func (doc *Document) Serialize(w io.Writer, dedot bool) error {
+	if dedot {
+		return doc.SerializeWith(w, DedotMapper{})
+	}
+	return doc.SerializeWith(w, FlatMapper{})
+}
+
+func (doc *Document) iterJSONFlat(w structform.Visitor) error {
+	if err := w.OnObjectStart(-1, structform.AnyType); err != nil {
+		return err
+	}
+	for i := range doc.fields {
+		fld := doc.fields[i]
+		if fld.value.kind == KindIgnore {
+			continue
+		}
+		if err := w.OnKey(fld.key); err != nil {
+			return err
+		}
+		if err := fld.value.iterJSON(w, false); err != nil {
+			return err
+		}
+	}
+	return w.OnObjectFinished()
+}
+
+// iterJSONDedot writes doc's fields as a nested JSON object, splitting each
+// dotted key into path segments and opening/closing nested objects as the
+// common namespace prefix between consecutive (sorted) keys changes.
+func (doc *Document) iterJSONDedot(w structform.Visitor) error {
+	if err := w.OnObjectStart(-1, structform.AnyType); err != nil {
+		return err
+	}
+
+	var stack []string
+	for i := range doc.fields {
+		fld := doc.fields[i]
+		if fld.value.kind == KindIgnore {
+			continue
+		}
+
+		segments := strings.Split(fld.key, ".")
+		namespace, leaf := segments[:len(segments)-1], segments[len(segments)-1]
+
+		common := 0
+		for common < len(stack) && common < len(namespace) && stack[common] == namespace[common] {
+			common++
+		}
+		for len(stack) > common {
+			if err := w.OnObjectFinished(); err != nil {
+				return err
+			}
+			stack = stack[:len(stack)-1]
+		}
+		for _, seg := range namespace[common:] {
+			if err := w.OnKey(seg); err != nil {
+				return err
+			}
+			if err := w.OnObjectStart(-1, structform.AnyType); err != nil {
+				return err
+			}
+			stack = append(stack, seg)
+		}
+
+		if err := w.OnKey(leaf); err != nil {
+			return err
+		}
+		if err := fld.value.iterJSON(w, true); err != nil {
+			return err
+		}
+	}
+
+	for range stack {
+		if err := w.OnObjectFinished(); err != nil {
+			return err
+		}
+	}
+	return w.OnObjectFinished()
+}
+
+func (v Value) iterJSON(w structform.Visitor, dedot bool) error {
+	switch v.kind {
+	case KindNil, KindIgnore:
+		return w.OnNil()
+	case KindBool:
+		return w.OnBool(v.primitive == 1)
+	case KindInt:
+		return w.OnInt64(int64(v.primitive))
+	case KindDouble:
+		d := math.Float64frombits(v.primitive)
+		if math.IsNaN(d) || math.IsInf(d, 0) {
+			// JSON has no representation for NaN/Inf; emit null rather than
+			// producing invalid JSON.
+			return w.OnNil()
+		}
+		return w.OnFloat64(d)
+	case KindString:
+		return w.OnString(v.str)
+	case KindTimestamp:
+		return w.OnString(v.ts.Format(time.RFC3339Nano))
+	case KindArr:
+		if err := w.OnArrayStart(len(v.arr), structform.AnyType); err != nil {
+			return err
+		}
+		for _, elem := range v.arr {
+			if err := elem.iterJSON(w, dedot); err != nil {
+				return err
+			}
+		}
+		return w.OnArrayFinished()
+	case KindObject:
+		if len(v.obj) == 0 {
+			return w.OnNil()
+		}
+		if err := w.OnObjectStart(len(v.obj), structform.AnyType); err != nil {
+			return err
+		}
+		for _, f := range v.obj {
+			if err := w.OnKey(f.key); err != nil {
+				return err
+			}
+			if err := f.value.iterJSON(w, dedot); err != nil {
+				return err
+			}
+		}
+		return w.OnObjectFinished()
+	default:
+		return w.OnNil()
+	}
+}
+
+// valueFromAttribute converts a pcommon.Value into a Value, preserving
+// structure (maps and slices are converted recursively, without flattening).
+func valueFromAttribute(attr pcommon.Value) Value {
+	switch attr.Type() {
+	case pcommon.ValueTypeEmpty:
+		return nilValue
+	case pcommon.ValueTypeStr:
+		return stringValue(attr.Str())
+	case pcommon.ValueTypeInt:
+		return intValue(attr.Int())
+	case pcommon.ValueTypeDouble:
+		return doubleValue(attr.Double())
+	case pcommon.ValueTypeBool:
+		return boolValue(attr.Bool())
+	case pcommon.ValueTypeSlice:
+		return arrFromAttributeSlice(attr.Slice())
+	case pcommon.ValueTypeMap:
+		return objFromAttributeMap(attr.Map())
+	default:
+		return stringValue(attr.AsString())
+	}
+}
+
+func arrFromAttributeSlice(s pcommon.Slice) Value {
+	if s.Len() == 0 {
+		return Value{kind: KindArr}
+	}
+	values := make([]Value, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		values[i] = valueFromAttribute(s.At(i))
+	}
+	return Value{kind: KindArr, arr: values}
+}
+
+func objFromAttributeMap(m pcommon.Map) Value {
+	if m.Len() == 0 {
+		return Value{kind: KindObject}
+	}
+	fields := make([]field, 0, m.Len())
+	m.Range(func(k string, v pcommon.Value) bool {
+		fields = append(fields, field{key: k, value: valueFromAttribute(v)})
+		return true
+	})
+	return Value{kind: KindObject, obj: fields}
+}
@@ -152,23 +152,34 @@ func TestObjectModel_Dedup(t *testing.T) {
 			},
 			want: Document{[]field{{"namespace.a", ignoreValue}, {"namespace.a", intValue(42)}, {"toplevel", stringValue("test")}}},
 		},
-		/*
-			"dedup in arrays": {
-				build: func() (doc Document) {
-					m := pcommon.NewMap()
-					m.PutInt("a", 1)
-					m.PutInt("c", 3)
-					m.PutInt("a", 2)
-					doc.Add("arr", ObjectValue(m))
-					return doc
-				},
-				want: Document{[]field{{"arr", arrValue(Value{kind: KindObject, obj: []field{
-					{"a", ignoreValue},
-					{"a", intValue(2)},
+		"dedup in arrays": {
+			build: func() (doc Document) {
+				doc.Add("arr", arrValue(Value{kind: KindObject, obj: []field{
+					{"a", intValue(1)},
 					{"c", intValue(3)},
-				}})}}},
+					{"a", intValue(2)},
+				}}))
+				return doc
 			},
-		*/
+			want: Document{[]field{{"arr", arrValue(Value{kind: KindObject, obj: []field{
+				{"a", ignoreValue},
+				{"a", intValue(2)},
+				{"c", intValue(3)},
+			}})}}},
+		},
+		"dedup in arrays of arrays of objects": {
+			build: func() (doc Document) {
+				doc.Add("arr", arrValue(arrValue(Value{kind: KindObject, obj: []field{
+					{"a", intValue(1)},
+					{"a", intValue(2)},
+				}})))
+				return doc
+			},
+			want: Document{[]field{{"arr", arrValue(arrValue(Value{kind: KindObject, obj: []field{
+				{"a", ignoreValue},
+				{"a", intValue(2)},
+			}}))}}},
+		},
 		"dedup mix of primitive and object lifts primitive": {
 			build: func() (doc Document) {
 				doc.AddInt("namespace", 1)
@@ -198,6 +209,254 @@ func TestObjectModel_Dedup(t *testing.T) {
 	}
 }
 
+func TestDocument_Merge(t *testing.T) {
+	tests := map[string]struct {
+		build func() (doc, other Document)
+		opts  MergeOptions
+		want  Document
+	}{
+		"disjoint namespaces are kept": {
+			build: func() (doc, other Document) {
+				doc.AddString("resource.service.name", "a")
+				other.AddString("resource.service.version", "1.0")
+				return
+			},
+			want: Document{[]field{
+				{"resource.service.name", stringValue("a")},
+				{"resource.service.version", stringValue("1.0")},
+			}},
+		},
+		"nested namespaces merge instead of colliding": {
+			build: func() (doc, other Document) {
+				doc.AddString("namespace.a", "from-doc")
+				m := pcommon.NewMap()
+				m.PutStr("b", "from-other")
+				other.AddAttributes("namespace", m)
+				return
+			},
+			want: Document{[]field{
+				{"namespace.a", stringValue("from-doc")},
+				{"namespace.b", stringValue("from-other")},
+			}},
+		},
+		"primitive collision: overwrite": {
+			build: func() (doc, other Document) {
+				doc.AddString("k", "old")
+				other.AddString("k", "new")
+				return
+			},
+			opts: MergeOptions{Mode: MergeOverwrite},
+			want: Document{[]field{{"k", stringValue("new")}}},
+		},
+		"primitive collision: preserve": {
+			build: func() (doc, other Document) {
+				doc.AddString("k", "old")
+				other.AddString("k", "new")
+				return
+			},
+			opts: MergeOptions{Mode: MergePreserve},
+			want: Document{[]field{{"k", stringValue("old")}}},
+		},
+		"primitive collision: append": {
+			build: func() (doc, other Document) {
+				doc.AddInt("k", 1)
+				other.AddInt("k", 2)
+				return
+			},
+			opts: MergeOptions{Mode: MergeAppend},
+			want: Document{[]field{{"k", arrValue(intValue(1), intValue(2))}}},
+		},
+		"arrays of scalars concatenate": {
+			build: func() (doc, other Document) {
+				doc.Add("k", arrValue(intValue(1), intValue(2)))
+				other.Add("k", arrValue(intValue(3)))
+				return
+			},
+			want: Document{[]field{{"k", arrValue(intValue(1), intValue(2), intValue(3))}}},
+		},
+		"arrays of objects merge element-wise by index": {
+			build: func() (doc, other Document) {
+				doc.Add("k", arrValue(
+					Value{kind: KindObject, obj: []field{{"a", stringValue("from-doc")}}},
+					Value{kind: KindObject, obj: []field{{"x", intValue(1)}}},
+				))
+				other.Add("k", arrValue(
+					Value{kind: KindObject, obj: []field{{"b", stringValue("from-other")}}},
+				))
+				return
+			},
+			want: Document{[]field{{"k", arrValue(
+				Value{kind: KindObject, obj: []field{{"a", stringValue("from-doc")}, {"b", stringValue("from-other")}}},
+				Value{kind: KindObject, obj: []field{{"x", intValue(1)}}},
+			)}}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, other := test.build()
+			doc.Sort()
+			doc.Dedup()
+			other.Sort()
+			other.Dedup()
+
+			doc.Merge(other, test.opts)
+			assert.Equal(t, test.want, doc)
+		})
+	}
+}
+
+func TestDocument_MergeAttributes_NamespaceLiftCollision(t *testing.T) {
+	// A single pcommon.Map can legally hold both a primitive at a key and a
+	// flattened child of that same key at once (e.g. "http" and
+	// "http.method"). MergeAttributes takes raw, unnormalized attributes -
+	// unlike the TestDocument_Merge cases above, which always Sort+Dedup
+	// both sides first - so Merge itself must resolve this the same way
+	// Dedup would (lifting the primitive to "http.value"), or it silently
+	// drops one side.
+	tests := map[string]struct {
+		build func(m pcommon.Map)
+	}{
+		"primitive added before its nested child": {
+			build: func(m pcommon.Map) {
+				m.PutStr("http", "foo")
+				m.PutStr("http.method", "GET")
+			},
+		},
+		"primitive added after its nested child": {
+			build: func(m pcommon.Map) {
+				m.PutStr("http.method", "GET")
+				m.PutStr("http", "foo")
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := pcommon.NewMap()
+			test.build(m)
+
+			var doc Document
+			doc.MergeAttributes("", m, MergeOptions{})
+
+			var buf strings.Builder
+			require.NoError(t, doc.Serialize(&buf, true))
+			assert.Equal(t, `{"http":{"method":"GET","value":"foo"}}`, buf.String())
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want Path
+	}{
+		"empty":               {in: "", want: nil},
+		"single segment":      {in: "a", want: Path{"a"}},
+		"dotted":              {in: "a.b.c", want: Path{"a", "b", "c"}},
+		"escaped literal dot": {in: `a\.b.c`, want: Path{"a.b", "c"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ParsePath(test.in)
+			assert.True(t, got.IsEqual(test.want))
+			if test.in != "" {
+				assert.Equal(t, test.in, got.String())
+			}
+		})
+	}
+}
+
+func TestDocument_Path(t *testing.T) {
+	t.Run("GetPath finds a flat field", func(t *testing.T) {
+		var doc Document
+		doc.AddString("a.b", "test")
+
+		v, ok := doc.GetPath(Path{"a", "b"})
+		require.True(t, ok)
+		assert.Equal(t, stringValue("test"), v)
+	})
+
+	t.Run("GetPath finds a value nested inside an object field", func(t *testing.T) {
+		var doc Document
+		doc.Add("a", Value{kind: KindObject, obj: []field{{"b", stringValue("test")}}})
+
+		v, ok := doc.GetPath(Path{"a", "b"})
+		require.True(t, ok)
+		assert.Equal(t, stringValue("test"), v)
+	})
+
+	t.Run("SetPath inserts in flat form", func(t *testing.T) {
+		var doc Document
+		doc.SetPath(Path{"a", "b"}, intValue(42))
+
+		assert.Equal(t, Document{[]field{{"a.b", intValue(42)}}}, doc)
+	})
+
+	t.Run("DeletePath removes a flat field", func(t *testing.T) {
+		var doc Document
+		doc.AddString("a.b", "test")
+		doc.AddString("c", "keep")
+
+		require.True(t, doc.DeletePath(Path{"a", "b"}))
+		assert.Equal(t, Document{[]field{{"c", stringValue("keep")}}}, doc)
+		assert.False(t, doc.DeletePath(Path{"a", "b"}))
+	})
+
+	t.Run("DeletePath removes a value nested inside an object field", func(t *testing.T) {
+		var doc Document
+		doc.Add("a", Value{kind: KindObject, obj: []field{
+			{"b", stringValue("test")},
+			{"c", stringValue("keep")},
+		}})
+
+		require.True(t, doc.DeletePath(Path{"a", "b"}))
+		v, ok := doc.GetPath(Path{"a", "b"})
+		assert.False(t, ok)
+		assert.Equal(t, Value{}, v)
+
+		v, ok = doc.GetPath(Path{"a", "c"})
+		require.True(t, ok)
+		assert.Equal(t, stringValue("keep"), v)
+	})
+
+	t.Run("escaped literal dot round-trips through Get/Set/Delete", func(t *testing.T) {
+		var doc Document
+		p := ParsePath(`a\.b.c`)
+
+		doc.SetPath(p, stringValue("test"))
+		v, ok := doc.GetPath(p)
+		require.True(t, ok)
+		assert.Equal(t, stringValue("test"), v)
+
+		require.True(t, doc.DeletePath(p))
+		_, ok = doc.GetPath(p)
+		assert.False(t, ok)
+	})
+
+	t.Run("RenamePath moves a value", func(t *testing.T) {
+		var doc Document
+		doc.AddString("old", "test")
+
+		doc.RenamePath(Path{"old"}, Path{"new"})
+		assert.Equal(t, Document{[]field{{"new", stringValue("test")}}}, doc)
+	})
+
+	t.Run("Walk visits every leaf value", func(t *testing.T) {
+		var doc Document
+		doc.AddString("a.b", "test")
+		doc.AddInt("c", 1)
+
+		var got []string
+		require.NoError(t, doc.Walk(func(p Path, _ Value) error {
+			got = append(got, p.String())
+			return nil
+		}))
+		assert.ElementsMatch(t, []string{"a.b", "c"}, got)
+	})
+}
+
 func TestValue_FromAttribute(t *testing.T) {
 	tests := map[string]struct {
 		in   pcommon.Value
@@ -364,6 +623,14 @@ func TestDocument_Serialize_Dedot(t *testing.T) {
 			},
 			want: `{"a":{"b":{"c":{"str":"test"}},"i":1}}`,
 		},
+		"array of objects is preserved, not split by dedot": {
+			attrs: map[string]any{
+				"arr": []any{
+					map[string]any{"a": 1, "b": 2},
+				},
+			},
+			want: `{"arr":[{"a":1,"b":2}]}`,
+		},
 	}
 
 	for name, test := range tests {
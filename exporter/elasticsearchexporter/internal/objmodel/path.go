@@ -0,0 +1,211 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objmodel // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/elasticsearchexporter/internal/objmodel"
+
+import "strings"
+
+// Path identifies a field within a Document by its sequence of key segments,
+// e.g. Path{"resource", "service.name"} addresses the "service.name" field
+// nested under "resource". A literal "." within a segment is escaped as `\.`
+// when a Path is parsed from, or rendered back to, a dotted string.
+type Path []string
+
+// ParsePath splits a dotted string into a Path, treating "\." as an escaped,
+// literal dot rather than a segment separator.
+func ParsePath(s string) Path {
+	if s == "" {
+		return nil
+	}
+
+	var segments []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '.':
+			cur.WriteByte('.')
+			i++
+		case c == '.':
+			segments = append(segments, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	segments = append(segments, cur.String())
+	return Path(segments)
+}
+
+// String renders p back into a dotted string, escaping any literal "." in a
+// segment so it round-trips through ParsePath.
+func (p Path) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+	segments := make([]string, len(p))
+	for i, seg := range p {
+		segments[i] = strings.ReplaceAll(seg, ".", `\.`)
+	}
+	return strings.Join(segments, ".")
+}
+
+// IsEqual reports whether p and other address the same field.
+func (p Path) IsEqual(other Path) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Paths is a list of Path, e.g. an include/exclude filter list.
+type Paths []Path
+
+// IsEqual reports whether ps and other contain the same paths, in the same
+// order.
+func (ps Paths) IsEqual(other Paths) bool {
+	if len(ps) != len(other) {
+		return false
+	}
+	for i := range ps {
+		if !ps[i].IsEqual(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetPath looks up the value addressed by p, whether it was stored flat
+// (e.g. a field keyed "a.b") or nested (a field keyed "a" holding an object
+// with a "b" field).
+func (doc *Document) GetPath(p Path) (Value, bool) {
+	for i := range doc.fields {
+		f := doc.fields[i]
+		if f.value.kind == KindIgnore {
+			continue
+		}
+		if v, ok := matchPath(ParsePath(f.key), p, f.value); ok {
+			return v, true
+		}
+	}
+	return Value{}, false
+}
+
+// matchPath checks fieldPath against the leading segments of p, descending
+// into v if p addresses a field nested inside it.
+func matchPath(fieldPath, p Path, v Value) (Value, bool) {
+	if len(p) < len(fieldPath) || !fieldPath.IsEqual(p[:len(fieldPath)]) {
+		return Value{}, false
+	}
+	rest := p[len(fieldPath):]
+	if len(rest) == 0 {
+		return v, true
+	}
+	if v.kind != KindObject {
+		return Value{}, false
+	}
+	for _, child := range v.obj {
+		if cv, ok := matchPath(Path{child.key}, rest, child.value); ok {
+			return cv, true
+		}
+	}
+	return Value{}, false
+}
+
+// SetPath inserts v as a new field addressed by p, in flat (dotted) form.
+// Dedup and Serialize(dedot=true) are responsible for reconciling the result
+// with any overlapping namespace already present in the document.
+func (doc *Document) SetPath(p Path, v Value) {
+	doc.Add(p.String(), v)
+}
+
+// DeletePath removes the field addressed by p, including one nested inside an
+// object value, and reports whether anything was removed.
+func (doc *Document) DeletePath(p Path) bool {
+	for i := range doc.fields {
+		f := &doc.fields[i]
+		if f.value.kind == KindIgnore {
+			continue
+		}
+		fieldPath := ParsePath(f.key)
+		if len(p) < len(fieldPath) || !fieldPath.IsEqual(p[:len(fieldPath)]) {
+			continue
+		}
+
+		rest := p[len(fieldPath):]
+		if len(rest) == 0 {
+			doc.fields = append(doc.fields[:i], doc.fields[i+1:]...)
+			return true
+		}
+		if nv, ok := deletePathIn(f.value, rest); ok {
+			f.value = nv
+			return true
+		}
+	}
+	return false
+}
+
+func deletePathIn(v Value, rest Path) (Value, bool) {
+	if v.kind != KindObject {
+		return v, false
+	}
+	for i, child := range v.obj {
+		if child.key != rest[0] {
+			continue
+		}
+		if len(rest) == 1 {
+			v.obj = append(append([]field{}, v.obj[:i]...), v.obj[i+1:]...)
+			return v, true
+		}
+		if nv, ok := deletePathIn(child.value, rest[1:]); ok {
+			v.obj[i].value = nv
+			return v, true
+		}
+		return v, false
+	}
+	return v, false
+}
+
+// RenamePath moves the value addressed by from to to. It is a no-op if from
+// does not address an existing value.
+func (doc *Document) RenamePath(from, to Path) {
+	v, ok := doc.GetPath(from)
+	if !ok {
+		return
+	}
+	doc.DeletePath(from)
+	doc.SetPath(to, v)
+}
+
+// Walk calls fn for every leaf value in the document, in field order,
+// including values nested inside object fields. Walk stops and returns the
+// first error fn returns.
+func (doc *Document) Walk(fn func(Path, Value) error) error {
+	for i := range doc.fields {
+		f := doc.fields[i]
+		if f.value.kind == KindIgnore {
+			continue
+		}
+		if err := walkValue(ParsePath(f.key), f.value, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkValue(p Path, v Value, fn func(Path, Value) error) error {
+	if v.kind != KindObject {
+		return fn(p, v)
+	}
+	for _, child := range v.obj {
+		childPath := append(append(Path{}, p...), child.key)
+		if err := walkValue(childPath, child.value, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objmodel // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/elasticsearchexporter/internal/objmodel"
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	structform "github.com/elastic/go-structform"
+	"github.com/elastic/go-structform/json"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// StreamEncoder incrementally builds a JSON document from one or more
+// pcommon.Maps, the way a Document does, but without ever materializing a
+// full []field slice or Document: Close drains a min-heap of pending
+// (key, value) pairs in sorted order and writes each field to the
+// underlying writer as soon as its key is known final, opening and closing
+// dedot namespace objects as the common key prefix changes - the same
+// nesting rule iterJSONDedot applies, just interleaved with resolving
+// collisions instead of run afterwards over a fully built field list.
+//
+// A StreamEncoder is for single use: call Close once all attributes have
+// been added.
+type StreamEncoder struct {
+	w     io.Writer
+	dedot bool
+	heap  streamHeap
+	seq   int
+}
+
+// NewStreamEncoder returns a StreamEncoder that will write a single JSON
+// object to w once Close is called.
+func NewStreamEncoder(w io.Writer, dedot bool) *StreamEncoder {
+	return &StreamEncoder{w: w, dedot: dedot}
+}
+
+// AddTimestamp adds a timestamp valued field.
+func (e *StreamEncoder) AddTimestamp(key string, ts time.Time) {
+	e.push(key, timestampValue(ts))
+}
+
+// AddAttribute adds a single value taken from a pcommon.Value, flattening it
+// under key if it is a map.
+func (e *StreamEncoder) AddAttribute(key string, v pcommon.Value) {
+	switch v.Type() {
+	case pcommon.ValueTypeEmpty:
+		// drop nil/empty attributes entirely, rather than emitting a null.
+	case pcommon.ValueTypeMap:
+		e.AddAttributes(key, v.Map())
+	default:
+		e.push(key, valueFromAttribute(v))
+	}
+}
+
+// AddAttributes flattens the given pcommon.Map, prefixing each resulting key
+// with key (using "." as a separator, omitted if key is empty).
+func (e *StreamEncoder) AddAttributes(key string, m pcommon.Map) {
+	m.Range(func(k string, v pcommon.Value) bool {
+		e.AddAttribute(flattenKey(key, k), v)
+		return true
+	})
+}
+
+func (e *StreamEncoder) push(key string, v Value) {
+	e.heap.push(streamItem{key: key, value: v, seq: e.seq})
+	e.seq++
+}
+
+// liftSeq is the sequence number given to a field re-pushed onto the heap by
+// the namespace-lift in Close, so that it always loses a tie against a real
+// field sharing its lifted key - matching the "last write wins" precedence
+// Document.Dedup gives an explicit field over one it lifted itself.
+const liftSeq = -1
+
+// Close drains the heap in sorted order and writes the resulting JSON object
+// to w, applying the same two collision rules Document.Dedup does:
+//
+//   - Two pushes sharing the exact same key: the last one (highest sequence
+//     number) wins.
+//   - A key that is itself a dotted namespace of other keys (e.g. a
+//     primitive value at "namespace" alongside "namespace.a"): it is
+//     re-pushed onto the heap as "namespace.value", so it competes for its
+//     correct sorted position among its new siblings instead of simply
+//     being emitted out of order.
+//
+// Each field is written out the moment no further push can still collide
+// with it, rather than after a batch sort over every field.
+//
+// One pathological shape is worth calling out: if a key is pushed more than
+// once *and* a later push names one of its children (e.g. two pushes of
+// "namespace" followed by one of "namespace.a"), Close collapses the
+// duplicate "namespace" pushes to a single value before lifting it to
+// "namespace.value". Document.Dedup's lift only ever compares adjacent
+// sorted fields, so taken on its own it instead lifts just the second
+// "namespace" occurrence, leaving the first behind as a stray top-level
+// "namespace" field alongside it. That stray field never reaches a caller
+// of Document.Serialize, though: SerializeWith re-sorts, re-lifts and
+// re-dedups its mapped fields a second time before writing them out (to
+// handle a Mapper renaming keys into a fresh collision), and that second
+// pass collapses the stray field the same way Close does. So the two paths
+// still agree on every byte Serialize ever produces; see
+// TestStreamEncoder_DuplicateThenLift.
+//
+// The StreamEncoder must not be used after Close is called.
+func (e *StreamEncoder) Close() error {
+	sw := &streamWriter{v: json.NewVisitor(e.w), dedot: e.dedot}
+	if err := sw.start(); err != nil {
+		return err
+	}
+
+	var pending field
+	havePending := false
+	for e.heap.Len() > 0 {
+		item := e.heap.pop()
+
+		switch {
+		case !havePending:
+			pending = field{key: item.key, value: item.value}
+			havePending = true
+
+		case item.key == pending.key:
+			pending.value = item.value
+
+		case strings.HasPrefix(item.key, pending.key+"."):
+			e.heap.push(streamItem{key: pending.key + ".value", value: pending.value, seq: liftSeq})
+			e.heap.push(item)
+			havePending = false
+
+		default:
+			if err := sw.write(pending); err != nil {
+				return err
+			}
+			pending = field{key: item.key, value: item.value}
+		}
+	}
+	if havePending {
+		if err := sw.write(pending); err != nil {
+			return err
+		}
+	}
+
+	return sw.finish()
+}
+
+// streamWriter writes a sequence of fields, sorted and already resolved of
+// any collision, to a structform.Visitor as a single JSON object - flat, or
+// with dotted keys expanded into nested objects as the common namespace
+// prefix between consecutive fields changes, mirroring iterJSONFlat and
+// iterJSONDedot.
+type streamWriter struct {
+	v     structform.Visitor
+	dedot bool
+	stack []string
+}
+
+func (sw *streamWriter) start() error {
+	return sw.v.OnObjectStart(-1, structform.AnyType)
+}
+
+func (sw *streamWriter) write(f field) error {
+	if f.value.kind == KindIgnore {
+		return nil
+	}
+	f.value.Sort()
+	f.value.Dedup()
+
+	if !sw.dedot {
+		if err := sw.v.OnKey(f.key); err != nil {
+			return err
+		}
+		return f.value.iterJSON(sw.v, false)
+	}
+
+	segments := strings.Split(f.key, ".")
+	namespace, leaf := segments[:len(segments)-1], segments[len(segments)-1]
+
+	common := 0
+	for common < len(sw.stack) && common < len(namespace) && sw.stack[common] == namespace[common] {
+		common++
+	}
+	for len(sw.stack) > common {
+		if err := sw.v.OnObjectFinished(); err != nil {
+			return err
+		}
+		sw.stack = sw.stack[:len(sw.stack)-1]
+	}
+	for _, seg := range namespace[common:] {
+		if err := sw.v.OnKey(seg); err != nil {
+			return err
+		}
+		if err := sw.v.OnObjectStart(-1, structform.AnyType); err != nil {
+			return err
+		}
+		sw.stack = append(sw.stack, seg)
+	}
+
+	if err := sw.v.OnKey(leaf); err != nil {
+		return err
+	}
+	return f.value.iterJSON(sw.v, true)
+}
+
+func (sw *streamWriter) finish() error {
+	for range sw.stack {
+		if err := sw.v.OnObjectFinished(); err != nil {
+			return err
+		}
+	}
+	return sw.v.OnObjectFinished()
+}
+
+// streamItem is a pending field in a StreamEncoder's heap: a key/value pair
+// together with the push sequence number used to break ties between equal
+// keys.
+type streamItem struct {
+	key   string
+	value Value
+	seq   int
+}
+
+// streamHeap is a binary min-heap of streamItem ordered by (key, seq),  so
+// popping it yields fields in the same order Document.Sort would, without
+// boxing each item through container/heap's interface{}-typed Push/Pop.
+type streamHeap struct {
+	items []streamItem
+}
+
+func (h *streamHeap) Len() int { return len(h.items) }
+
+func (h *streamHeap) less(i, j int) bool {
+	if h.items[i].key != h.items[j].key {
+		return h.items[i].key < h.items[j].key
+	}
+	return h.items[i].seq < h.items[j].seq
+}
+
+func (h *streamHeap) push(item streamItem) {
+	h.items = append(h.items, item)
+	for i := len(h.items) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			break
+		}
+		h.items[i], h.items[parent] = h.items[parent], h.items[i]
+		i = parent
+	}
+}
+
+func (h *streamHeap) pop() streamItem {
+	n := len(h.items) - 1
+	h.items[0], h.items[n] = h.items[n], h.items[0]
+	top := h.items[n]
+	h.items = h.items[:n]
+
+	for i := 0; ; {
+		left, right := 2*i+1, 2*i+2
+		smallest := i
+		if left < n && h.less(left, smallest) {
+			smallest = left
+		}
+		if right < n && h.less(right, smallest) {
+			smallest = right
+		}
+		if smallest == i {
+			break
+		}
+		h.items[i], h.items[smallest] = h.items[smallest], h.items[i]
+		i = smallest
+	}
+	return top
+}
@@ -0,0 +1,193 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objmodel
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestStreamEncoder_MatchesDocument(t *testing.T) {
+	tests := map[string]struct {
+		attrs map[string]any
+	}{
+		"no nesting with multiple fields": {
+			attrs: map[string]any{"a": "test", "b": 1},
+		},
+		"shared prefix": {
+			attrs: map[string]any{"a.str": "test", "a.i": 1},
+		},
+		"nested maps": {
+			attrs: map[string]any{"a": map[string]any{"str": "test", "i": 1}},
+		},
+		"primitive collides with a nested namespace": {
+			attrs: map[string]any{"namespace": 1, "namespace.a": 2},
+		},
+		"namespace lift collides with an explicit .value field": {
+			attrs: map[string]any{"namespace": 1, "namespace.a": 2, "namespace.value": 3},
+		},
+		"array of objects is sorted like the batch path": {
+			attrs: map[string]any{"arr": []any{map[string]any{"c": 3, "a": 1}}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, dedot := range []bool{false, true} {
+				m := pcommon.NewMap()
+				require.NoError(t, m.FromRaw(test.attrs))
+
+				var doc Document
+				doc.AddAttributes("", m)
+				doc.Sort()
+				doc.Dedup()
+				var wantBuf strings.Builder
+				require.NoError(t, doc.Serialize(&wantBuf, dedot))
+
+				var gotBuf strings.Builder
+				enc := NewStreamEncoder(&gotBuf, dedot)
+				enc.AddAttributes("", m)
+				require.NoError(t, enc.Close())
+
+				assert.Equal(t, wantBuf.String(), gotBuf.String())
+			}
+		})
+	}
+
+	t.Run("a later AddAttribute wins over an earlier one with the same key", func(t *testing.T) {
+		var doc Document
+		doc.AddInt("a", 1)
+		doc.AddInt("a", 2)
+		doc.Sort()
+		doc.Dedup()
+		var wantBuf strings.Builder
+		require.NoError(t, doc.Serialize(&wantBuf, false))
+
+		var gotBuf strings.Builder
+		enc := NewStreamEncoder(&gotBuf, false)
+		enc.AddAttribute("a", pcommon.NewValueInt(1))
+		enc.AddAttribute("a", pcommon.NewValueInt(2))
+		require.NoError(t, enc.Close())
+
+		assert.Equal(t, `{"a":2}`, gotBuf.String())
+		assert.Equal(t, wantBuf.String(), gotBuf.String())
+	})
+}
+
+// TestStreamEncoder_ArrayOfObjectsWithDuplicateKeys mirrors
+// TestObjectModel_Dedup's "dedup in arrays" case: pcommon.Map's Put* API
+// always overwrites, so a genuine duplicate key inside one object can only
+// be constructed by pushing fields directly, bypassing AddAttributes.
+func TestStreamEncoder_ArrayOfObjectsWithDuplicateKeys(t *testing.T) {
+	arr := arrValue(Value{kind: KindObject, obj: []field{
+		{"a", intValue(1)},
+		{"c", intValue(3)},
+		{"a", intValue(2)},
+	}})
+
+	for _, dedot := range []bool{false, true} {
+		var doc Document
+		doc.Add("arr", arr)
+		doc.Sort()
+		doc.Dedup()
+		var wantBuf strings.Builder
+		require.NoError(t, doc.Serialize(&wantBuf, dedot))
+
+		var gotBuf strings.Builder
+		enc := NewStreamEncoder(&gotBuf, dedot)
+		enc.push("arr", arr)
+		require.NoError(t, enc.Close())
+
+		assert.Equal(t, wantBuf.String(), gotBuf.String())
+	}
+}
+
+// TestStreamEncoder_DuplicateThenLift covers the pathological shape called
+// out on Close: two pushes of the same key followed by a push naming one of
+// its children. Close's single pass over the heap collapses the duplicate
+// before lifting it, while Document.Dedup's adjacent-only lift - taken on
+// its own - leaves a stray top-level field behind it instead. Document.
+// Serialize never exposes that stray field, though, since SerializeWith
+// re-lifts and re-dedups its mapped fields a second time before writing
+// them out, so the two paths still agree on every byte Serialize produces.
+func TestStreamEncoder_DuplicateThenLift(t *testing.T) {
+	for _, dedot := range []bool{false, true} {
+		var doc Document
+		doc.AddInt("namespace", 1)
+		doc.AddInt("namespace", 2)
+		doc.AddInt("namespace.a", 3)
+		doc.Sort()
+		doc.Dedup()
+		var wantBuf strings.Builder
+		require.NoError(t, doc.Serialize(&wantBuf, dedot))
+
+		var gotBuf strings.Builder
+		enc := NewStreamEncoder(&gotBuf, dedot)
+		enc.AddAttribute("namespace", pcommon.NewValueInt(1))
+		enc.AddAttribute("namespace", pcommon.NewValueInt(2))
+		enc.AddAttribute("namespace.a", pcommon.NewValueInt(3))
+		require.NoError(t, enc.Close())
+
+		assert.Equal(t, wantBuf.String(), gotBuf.String())
+	}
+
+	// Dedup alone (without going through Serialize/SerializeWith) does
+	// leave the stray top-level field described above - this is what Close
+	// avoids by collapsing the duplicate before ever lifting it.
+	var doc Document
+	doc.AddInt("namespace", 1)
+	doc.AddInt("namespace", 2)
+	doc.AddInt("namespace.a", 3)
+	doc.Sort()
+	doc.Dedup()
+	assert.Equal(t, Document{[]field{
+		{"namespace", intValue(1)},
+		{"namespace.a", intValue(3)},
+		{"namespace.value", intValue(2)},
+	}}, doc)
+}
+
+func synthetic500AttrMap() pcommon.Map {
+	m := pcommon.NewMap()
+	for i := 0; i < 500; i++ {
+		m.PutStr(fmt.Sprintf("attributes.field_%03d", i), fmt.Sprintf("value-%d", i))
+	}
+	return m
+}
+
+func BenchmarkDocument_Serialize_500Attributes(b *testing.B) {
+	m := synthetic500AttrMap()
+	var buf strings.Builder
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		var doc Document
+		doc.AddAttributes("", m)
+		doc.Dedup()
+		if err := doc.Serialize(&buf, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStreamEncoder_500Attributes(b *testing.B) {
+	m := synthetic500AttrMap()
+	var buf strings.Builder
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		enc := NewStreamEncoder(&buf, true)
+		enc.AddAttributes("", m)
+		if err := enc.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}